@@ -1,9 +1,13 @@
 package main
 
 import (
+	"context"
 	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"os/signal"
@@ -86,9 +90,149 @@ func (sa *sliceArg) Set(arg string) error {
 	return nil
 }
 
-var (
-	packetModeTruncation int = 100
-)
+// armHWTrap finds `name` among every devlink hardware trap on the system
+// and switches its action to "trap", so drops it sees show up as
+// CMD_PACKET_ALERT with an ATTR_ORIGIN of ORIGIN_HW.
+func armHWTrap(name string) error {
+	dl, err := dropspy.NewDevlink()
+	if err != nil {
+		return fmt.Errorf("connect to devlink: %w", err)
+	}
+	defer dl.Close()
+
+	traps, err := dl.Traps()
+	if err != nil {
+		return fmt.Errorf("list traps: %w", err)
+	}
+
+	found := false
+
+	for _, t := range traps {
+		if t.Name != name {
+			continue
+		}
+
+		found = true
+
+		if err := dl.SetTrapAction(t.Bus, t.Device, t.Name, "trap"); err != nil {
+			return fmt.Errorf("%s/%s: %w", t.Bus, t.Device, err)
+		}
+	}
+
+	if !found {
+		return fmt.Errorf("no such trap")
+	}
+
+	return nil
+}
+
+// dropRecord is one NDJSON line emitted by "-output json".
+type dropRecord struct {
+	Timestamp time.Time `json:"timestamp"`
+	Ifindex   uint32    `json:"ifindex"`
+	Iface     string    `json:"iface"`
+	Symbol    string    `json:"symbol"`
+	Proto     uint16    `json:"proto"`
+	Length    uint32    `json:"length"`
+	Payload   string    `json:"payload_hex"`
+}
+
+// recorder writes out one PacketAlert per drop, in whatever format
+// "-output" selected.
+type recorder interface {
+	Record(pa *dropspy.PacketAlert) error
+	Close() error
+}
+
+type textRecorder struct {
+	links    map[uint32]string
+	resolver *dropspy.SymbolResolver
+	printHex bool
+}
+
+func (r *textRecorder) Record(pa *dropspy.PacketAlert) error {
+	sym := pa.Symbol()
+	if r.resolver != nil {
+		sym = pa.ResolvedSymbol(r.resolver)
+	}
+
+	if pa.Origin() == dropspy.OriginHW {
+		log.Printf("drop on iface:%s (hw trap:%s/%s)", r.links[pa.Link()], pa.HWTrapGroup(), pa.HWTrapName())
+	} else {
+		log.Printf("drop on iface:%s at %s:%016x", r.links[pa.Link()], sym, pa.PC())
+	}
+
+	if r.printHex {
+		fmt.Println(hex.Dump(pa.L3Packet()))
+	}
+
+	return nil
+}
+
+func (r *textRecorder) Close() error { return nil }
+
+type jsonRecorder struct {
+	enc      *json.Encoder
+	links    map[uint32]string
+	resolver *dropspy.SymbolResolver
+}
+
+func (r *jsonRecorder) Record(pa *dropspy.PacketAlert) error {
+	sym := pa.Symbol()
+	if r.resolver != nil {
+		sym = pa.ResolvedSymbol(r.resolver)
+	}
+
+	return r.enc.Encode(dropRecord{
+		Timestamp: pa.Timestamp(),
+		Ifindex:   pa.Link(),
+		Iface:     r.links[pa.Link()],
+		Symbol:    sym,
+		Proto:     pa.Proto(),
+		Length:    pa.Length(),
+		Payload:   hex.EncodeToString(pa.Packet()),
+	})
+}
+
+func (r *jsonRecorder) Close() error { return nil }
+
+type pcapRecorder struct {
+	handle *pcap.Handle
+	dumper *pcap.Dumper
+}
+
+func newPcapRecorder(path string, snaplen int) (*pcapRecorder, error) {
+	handle, err := pcap.OpenDead(layers.LinkTypeEthernet, int32(snaplen))
+	if err != nil {
+		return nil, fmt.Errorf("open dead handle: %w", err)
+	}
+
+	dumper, err := handle.NewDumper(path)
+	if err != nil {
+		handle.Close()
+		return nil, fmt.Errorf("open dump file: %w", err)
+	}
+
+	return &pcapRecorder{handle: handle, dumper: dumper}, nil
+}
+
+func (r *pcapRecorder) Record(pa *dropspy.PacketAlert) error {
+	packet := pa.Packet()
+
+	r.dumper.WritePacket(gopacket.CaptureInfo{
+		Timestamp:     pa.Timestamp(),
+		CaptureLength: len(packet),
+		Length:        int(pa.Length()),
+	}, packet)
+
+	return nil
+}
+
+func (r *pcapRecorder) Close() error {
+	r.dumper.Close()
+	r.handle.Close()
+	return nil
+}
 
 func main() {
 	var (
@@ -99,6 +243,13 @@ func main() {
 		maxDrops uint64
 		timeout  string
 		hw, sw   bool
+		hwtrap   string
+
+		mode        string
+		trunc, qlen uint
+
+		output  string
+		outfile string
 
 		filter filter
 
@@ -115,12 +266,26 @@ func main() {
 	flag.BoolVar(&hw, "hw", true, "record hardware drops")
 	flag.BoolVar(&sw, "sw", true, "record software drops")
 	flag.BoolVar(&printHex, "hex", false, "print hex dumps of matching packets")
+	flag.StringVar(&hwtrap, "hwtrap", "", "arm this devlink hardware trap before monitoring (may match on multiple devices)")
+	flag.StringVar(&mode, "mode", "packet", "alert mode: packet or summary")
+	flag.UintVar(&trunc, "trunc", 100, "packet-mode truncation length, in bytes")
+	flag.UintVar(&qlen, "qlen", 4096, "kernel alert queue length")
+	flag.StringVar(&output, "output", "text", "output format for packet-mode drops: text, json, or pcap")
+	flag.StringVar(&outfile, "outfile", "", "output file for -output json/pcap (default: stdout for json, ./drops.pcap for pcap)")
 
 	flag.Parse()
 
+	var alertMode uint8 = dropspy.ALERT_MODE_PACKET
+	if mode == "summary" {
+		alertMode = dropspy.ALERT_MODE_SUMMARY
+	} else if mode != "packet" {
+		fmt.Fprintf(os.Stderr, "-mode must be 'packet' or 'summary', got %q\n", mode)
+		os.Exit(1)
+	}
+
 	pcapExpr := strings.Join(flag.Args(), " ")
 	if pcapExpr != "" {
-		filter.bpf, err = pcap.NewBPF(layers.LinkTypeEthernet, packetModeTruncation, pcapExpr)
+		filter.bpf, err = pcap.NewBPF(layers.LinkTypeEthernet, int(trunc), pcapExpr)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "pcap expression: %s\n", err)
 			os.Exit(1)
@@ -194,7 +359,18 @@ func main() {
 		}
 	}
 
-	session, err := dropspy.NewSession()
+	if hwtrap != "" {
+		if err := armHWTrap(hwtrap); err != nil {
+			fmt.Fprintf(os.Stderr, "arm hwtrap %s: %s\n", hwtrap, err)
+			os.Exit(1)
+		}
+	}
+
+	session, err := dropspy.NewHandle(
+		dropspy.WithAlertMode(alertMode),
+		dropspy.WithTruncationLength(uint32(trunc)),
+		dropspy.WithQueueLength(uint32(qlen)),
+	)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "connect to drop_mon: %s\n", err)
 		os.Exit(1)
@@ -211,6 +387,7 @@ func main() {
 
 	defer func() {
 		session.Stop(true, true)
+		session.Close()
 	}()
 
 	session.Stop(true, true)
@@ -235,29 +412,123 @@ func main() {
 
 	dropCount := uint64(0)
 
-	for {
-		err = session.ReadUntil(deadline, func(pa dropspy.PacketAlert) bool {
-			if filter.Match(&pa) {
-				dropCount += 1
+	if alertMode == dropspy.ALERT_MODE_SUMMARY {
+		for {
+			err = session.ReadSummaryUntil(deadline, func(sa dropspy.SummaryAlert) bool {
+				for _, pt := range sa.Points {
+					dropCount++
 
-				log.Printf("drop on iface:%s at %s:%016x", links[pa.Link()], pa.Symbol(), pa.PC())
-				if printHex {
-					fmt.Println(hex.Dump(pa.L3Packet()))
-				}
+					log.Printf("drops at %s:%016x: %d", pt.Symbol, pt.PC, pt.DropCount)
 
-				if maxDrops != 0 && dropCount == maxDrops {
-					fmt.Fprintf(os.Stderr, "maximum drops reached, exiting\n")
-					return false
+					if maxDrops != 0 && dropCount >= maxDrops {
+						fmt.Fprintf(os.Stderr, "maximum drops reached, exiting\n")
+						return false
+					}
 				}
+
+				return true
+			})
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "read: %s\n", err)
+				time.Sleep(250 * time.Millisecond)
+			} else {
+				return
+			}
+		}
+	}
+
+	resolver, err := dropspy.NewSymbolResolver()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: symbol resolution disabled: %s\n", err)
+		resolver = nil
+	}
+
+	rec, err := newRecorder(output, outfile, int(trunc), links, resolver, printHex)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "output: %s\n", err)
+		os.Exit(1)
+	}
+	defer rec.Close()
+
+	ctx := context.Background()
+
+	var cancel context.CancelFunc
+
+	if !deadline.IsZero() {
+		ctx, cancel = context.WithDeadline(ctx, deadline)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	defer cancel()
+
+	alerts, errs := session.Subscribe(ctx)
+
+	for {
+		select {
+		case pa, ok := <-alerts:
+			if !ok {
+				return
+			}
+
+			if !filter.Match(&pa) {
+				continue
+			}
+
+			dropCount++
+
+			if err := rec.Record(&pa); err != nil {
+				fmt.Fprintf(os.Stderr, "record drop: %s\n", err)
+			}
+
+			if maxDrops != 0 && dropCount == maxDrops {
+				fmt.Fprintf(os.Stderr, "maximum drops reached, exiting\n")
+				return
+			}
+		case err, ok := <-errs:
+			if !ok {
+				return
+			}
+
+			var overflow *dropspy.QueueOverflowError
+			if errors.As(err, &overflow) {
+				fmt.Fprintf(os.Stderr, "warning: %s\n", err)
+				continue
 			}
 
-			return true
-		})
-		if err != nil {
 			fmt.Fprintf(os.Stderr, "read: %s\n", err)
-			time.Sleep(250 * time.Millisecond)
-		} else {
 			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// newRecorder builds the recorder selected by -output.
+func newRecorder(output, outfile string, snaplen int, links map[uint32]string, resolver *dropspy.SymbolResolver, printHex bool) (recorder, error) {
+	switch output {
+	case "text":
+		return &textRecorder{links: links, resolver: resolver, printHex: printHex}, nil
+	case "json":
+		w := io.Writer(os.Stdout)
+
+		if outfile != "" {
+			f, err := os.Create(outfile)
+			if err != nil {
+				return nil, fmt.Errorf("create %s: %w", outfile, err)
+			}
+
+			w = f
 		}
+
+		return &jsonRecorder{enc: json.NewEncoder(w), links: links, resolver: resolver}, nil
+	case "pcap":
+		path := outfile
+		if path == "" {
+			path = "drops.pcap"
+		}
+
+		return newPcapRecorder(path, snaplen)
+	default:
+		return nil, fmt.Errorf("unknown -output %q (want text, json, or pcap)", output)
 	}
 }
@@ -0,0 +1,262 @@
+package dropspy
+
+import (
+	"fmt"
+
+	"github.com/mdlayher/genetlink"
+	"github.com/mdlayher/netlink"
+)
+
+// these constants are the trap-related subset of the "devlink" generic
+// netlink family, pulled out of 5.6 mainline include/uapi/linux/devlink.h;
+// we don't model the rest of devlink (ports, params, health, &c) here.
+
+const (
+	DEVLINK_CMD_TRAP_GET = 61 + iota
+	DEVLINK_CMD_TRAP_SET
+	DEVLINK_CMD_TRAP_NEW
+	DEVLINK_CMD_TRAP_DEL
+	DEVLINK_CMD_TRAP_GROUP_GET
+	DEVLINK_CMD_TRAP_GROUP_SET
+	DEVLINK_CMD_TRAP_GROUP_NEW
+	DEVLINK_CMD_TRAP_GROUP_DEL
+)
+
+const (
+	DEVLINK_ATTR_BUS_NAME        = 1   /* string */
+	DEVLINK_ATTR_DEV_NAME        = 2   /* string */
+	DEVLINK_ATTR_TRAP_NAME       = 139 /* string */
+	DEVLINK_ATTR_TRAP_ACTION     = 140 /* u8 */
+	DEVLINK_ATTR_TRAP_TYPE       = 141 /* u8 */
+	DEVLINK_ATTR_TRAP_GENERIC    = 142 /* flag */
+	DEVLINK_ATTR_TRAP_GROUP_NAME = 144 /* string */
+)
+
+const (
+	DEVLINK_TRAP_ACTION_DROP = iota
+	DEVLINK_TRAP_ACTION_TRAP
+	DEVLINK_TRAP_ACTION_MIRROR
+)
+
+const (
+	DEVLINK_TRAP_TYPE_DROP = iota
+	DEVLINK_TRAP_TYPE_EXCEPTION
+	DEVLINK_TRAP_TYPE_CONTROL
+)
+
+var trapActionNames = map[uint8]string{
+	DEVLINK_TRAP_ACTION_DROP:   "drop",
+	DEVLINK_TRAP_ACTION_TRAP:   "trap",
+	DEVLINK_TRAP_ACTION_MIRROR: "mirror",
+}
+
+var trapActionValues = map[string]uint8{
+	"drop":   DEVLINK_TRAP_ACTION_DROP,
+	"trap":   DEVLINK_TRAP_ACTION_TRAP,
+	"mirror": DEVLINK_TRAP_ACTION_MIRROR,
+}
+
+var trapTypeNames = map[uint8]string{
+	DEVLINK_TRAP_TYPE_DROP:      "drop",
+	DEVLINK_TRAP_TYPE_EXCEPTION: "exception",
+	DEVLINK_TRAP_TYPE_CONTROL:   "control",
+}
+
+// Devlink wraps a genetlink.Conn bound to the "devlink" family, and knows
+// how to enumerate and arm/disarm the hardware traps and trap groups it
+// exposes (see `devlink trap` in iproute2).
+type Devlink struct {
+	conn *genetlink.Conn
+	fam  uint16
+}
+
+// NewDevlink connects to generic netlink and looks up the devlink family.
+func NewDevlink() (*Devlink, error) {
+	conn, err := genetlink.Dial(nil)
+	if err != nil {
+		return nil, fmt.Errorf("devlink: %w", err)
+	}
+
+	fam, err := conn.GetFamily("devlink")
+	if err != nil {
+		return nil, fmt.Errorf("devlink: %w", err)
+	}
+
+	return &Devlink{
+		conn: conn,
+		fam:  fam.ID,
+	}, nil
+}
+
+// Close releases the underlying netlink socket.
+func (d *Devlink) Close() error {
+	return d.conn.Close()
+}
+
+// Trap describes a single hardware trap registered by a devlink instance.
+type Trap struct {
+	Bus, Device string
+	Name        string
+	Group       string
+	Type        string
+	Generic     bool
+	Action      string
+}
+
+// TrapGroup describes a hardware trap group registered by a devlink
+// instance.
+type TrapGroup struct {
+	Bus, Device string
+	Name        string
+}
+
+func (d *Devlink) dump(cmd uint8) ([]genetlink.Message, error) {
+	ms, err := d.conn.Execute(genetlink.Message{
+		Header: genetlink.Header{
+			Command: cmd,
+		},
+	}, d.fam, netlink.Request|netlink.Dump)
+	if err != nil {
+		return nil, fmt.Errorf("dump: %w", err)
+	}
+
+	return ms, nil
+}
+
+// Traps enumerates every hardware trap registered across every devlink
+// instance on the system (name, generic-vs-driver, current action, and
+// the group it belongs to).
+func (d *Devlink) Traps() ([]Trap, error) {
+	ms, err := d.dump(DEVLINK_CMD_TRAP_GET)
+	if err != nil {
+		return nil, fmt.Errorf("traps: %w", err)
+	}
+
+	var traps []Trap
+
+	for _, m := range ms {
+		t, err := decodeTrap(m.Data)
+		if err != nil {
+			return nil, fmt.Errorf("traps: %w", err)
+		}
+
+		traps = append(traps, t)
+	}
+
+	return traps, nil
+}
+
+// TrapGroups enumerates every hardware trap group registered across every
+// devlink instance on the system.
+func (d *Devlink) TrapGroups() ([]TrapGroup, error) {
+	ms, err := d.dump(DEVLINK_CMD_TRAP_GROUP_GET)
+	if err != nil {
+		return nil, fmt.Errorf("trap groups: %w", err)
+	}
+
+	var groups []TrapGroup
+
+	for _, m := range ms {
+		dec, err := netlink.NewAttributeDecoder(m.Data)
+		if err != nil {
+			return nil, fmt.Errorf("trap groups: %w", err)
+		}
+
+		var g TrapGroup
+
+		for dec.Next() {
+			switch dec.Type() {
+			case DEVLINK_ATTR_BUS_NAME:
+				g.Bus = dec.String()
+			case DEVLINK_ATTR_DEV_NAME:
+				g.Device = dec.String()
+			case DEVLINK_ATTR_TRAP_GROUP_NAME:
+				g.Name = dec.String()
+			}
+		}
+
+		if err := dec.Err(); err != nil {
+			return nil, fmt.Errorf("trap groups: %w", err)
+		}
+
+		groups = append(groups, g)
+	}
+
+	return groups, nil
+}
+
+func decodeTrap(raw []byte) (Trap, error) {
+	dec, err := netlink.NewAttributeDecoder(raw)
+	if err != nil {
+		return Trap{}, fmt.Errorf("decode: %w", err)
+	}
+
+	var t Trap
+
+	for dec.Next() {
+		switch dec.Type() {
+		case DEVLINK_ATTR_BUS_NAME:
+			t.Bus = dec.String()
+		case DEVLINK_ATTR_DEV_NAME:
+			t.Device = dec.String()
+		case DEVLINK_ATTR_TRAP_NAME:
+			t.Name = dec.String()
+		case DEVLINK_ATTR_TRAP_GROUP_NAME:
+			t.Group = dec.String()
+		case DEVLINK_ATTR_TRAP_TYPE:
+			t.Type = trapTypeNames[dec.Uint8()]
+		case DEVLINK_ATTR_TRAP_ACTION:
+			t.Action = trapActionNames[dec.Uint8()]
+		case DEVLINK_ATTR_TRAP_GENERIC:
+			t.Generic = dec.Flag()
+		}
+	}
+
+	if err := dec.Err(); err != nil {
+		return Trap{}, fmt.Errorf("decode: %w", err)
+	}
+
+	return t, nil
+}
+
+func (d *Devlink) setAction(cmd uint8, bus, dev string, nameAttr uint16, name, action string) error {
+	val, ok := trapActionValues[action]
+	if !ok {
+		return fmt.Errorf("set action: unknown action %q", action)
+	}
+
+	enc := netlink.NewAttributeEncoder()
+	enc.String(DEVLINK_ATTR_BUS_NAME, bus)
+	enc.String(DEVLINK_ATTR_DEV_NAME, dev)
+	enc.String(nameAttr, name)
+	enc.Uint8(DEVLINK_ATTR_TRAP_ACTION, val)
+
+	raw, err := enc.Encode()
+	if err != nil {
+		return fmt.Errorf("set action: %w", err)
+	}
+
+	_, err = d.conn.Execute(genetlink.Message{
+		Header: genetlink.Header{
+			Command: cmd,
+		},
+		Data: raw,
+	}, d.fam, netlink.Request|netlink.Acknowledge)
+	if err != nil {
+		return fmt.Errorf("set action: %w", err)
+	}
+
+	return nil
+}
+
+// SetTrapAction arms or disarms a single named trap on the given devlink
+// device ("drop", "trap", or "mirror").
+func (d *Devlink) SetTrapAction(bus, dev, trap, action string) error {
+	return d.setAction(DEVLINK_CMD_TRAP_SET, bus, dev, DEVLINK_ATTR_TRAP_NAME, trap, action)
+}
+
+// SetGroupAction arms or disarms every trap in a group on the given
+// devlink device ("drop", "trap", or "mirror").
+func (d *Devlink) SetGroupAction(bus, dev, group, action string) error {
+	return d.setAction(DEVLINK_CMD_TRAP_GROUP_SET, bus, dev, DEVLINK_ATTR_TRAP_GROUP_NAME, group, action)
+}
@@ -67,28 +67,64 @@ const (
 	ORIGIN_SW = 0
 	ORIGIN_HW = 1
 
-	CFG_ALERT_COUNT = 1
-	CFG_ALERT_DELAY = 2
+	defaultTruncLen = 100
+	defaultQueueLen = 4096
 )
 
+// Option configures a Session at construction time; see WithTruncationLength,
+// WithQueueLength and WithAlertMode.
+type Option func(*Session)
+
+// WithAlertMode selects packet-mode (ALERT_MODE_PACKET, the default) or
+// summary-mode (ALERT_MODE_SUMMARY) alerting.
+func WithAlertMode(mode uint8) Option {
+	return func(s *Session) { s.alertMode = mode }
+}
+
+// WithTruncationLength overrides how many bytes of a dropped packet's
+// payload the kernel hands back (default 100). Only meaningful in packet
+// mode.
+func WithTruncationLength(n uint32) Option {
+	return func(s *Session) { s.truncLen = n }
+}
+
+// WithQueueLength overrides the depth of the kernel's internal alert
+// queue (default 4096); alerts are dropped silently once it's full.
+func WithQueueLength(n uint32) Option {
+	return func(s *Session) { s.queueLen = n }
+}
+
 // Session wraps a genetlink.Conn and looks up the DM_NET family
 // from the generic netlink registry
 type Session struct {
 	conn  *genetlink.Conn
 	fam   uint16
 	group uint32
+
+	alertMode          uint8
+	truncLen, queueLen uint32
 }
 
 // NewSession connects to generic netlink and looks up the DM_NET
-// family so we can issue requests
-func NewSession() (*Session, error) {
+// family so we can issue requests. By default it configures packet-mode
+// alerting with a 100-byte truncation length and a 4096-entry queue;
+// pass Options (WithAlertMode, WithTruncationLength, WithQueueLength)
+// to override any of that.
+func NewSession(opts ...Option) (*Session, error) {
 	conn, err := genetlink.Dial(nil)
 	if err != nil {
 		return nil, fmt.Errorf("session: %w", err)
 	}
 
 	s := &Session{
-		conn: conn,
+		conn:      conn,
+		alertMode: ALERT_MODE_PACKET,
+		truncLen:  defaultTruncLen,
+		queueLen:  defaultQueueLen,
+	}
+
+	for _, opt := range opts {
+		opt(s)
 	}
 
 	f, g, err := s.dropMonitorLookup()
@@ -102,6 +138,11 @@ func NewSession() (*Session, error) {
 	return s, nil
 }
 
+// Close releases the Session's underlying netlink socket.
+func (s *Session) Close() error {
+	return s.conn.Close()
+}
+
 func (s *Session) dropMonitorLookup() (famid uint16, group uint32, err error) {
 	fam, err := s.conn.GetFamily("NET_DM")
 	if err != nil {
@@ -141,9 +182,9 @@ func decodeConfig(raw []byte) (map[int]interface{}, error) {
 	return ret, nil
 }
 
-// Config returns a raw bundle of attrs (see ATTR_ constants)
-// holding the current DM_NET configuration (which is just the
-// alert mode and the packet snap length and queue length)
+// Config returns a raw bundle of attrs (see ATTR_ constants) holding the
+// current DM_NET configuration: alert mode, packet snap length, and
+// queue length.
 func (s *Session) Config() (map[int]interface{}, error) {
 	err := s.req(CMD_CONFIG_GET, nil, false)
 	if err != nil {
@@ -178,11 +219,11 @@ func (s *Session) req(cmd uint8, data []byte, ack bool) error {
 	return err
 }
 
-// Start puts DM_NET into packet alerting mode (so we get per-packet
-// alerts, and the raw contents of dropped packets), issues
-// an acknowledged CMD_START to start monitoring, and then
-// joins the GRP_ALERT netlink multicast group to read alerts. DM_NET alerting needs
-// to be stopped for this to work.
+// Start configures DM_NET per the Session's alert mode (packet or
+// summary; see NewSession's Options), issues an acknowledged CMD_START
+// to start monitoring, and then joins the GRP_ALERT netlink multicast
+// group to read alerts. DM_NET alerting needs to be stopped for this to
+// work.
 //
 // `sw` and `hw` enable/disable software and hardware drop monitoring,
 // respectively; hardware drops are done by offload hardware rather than
@@ -196,9 +237,9 @@ func (s *Session) Start(sw, hw bool) error {
 		return fmt.Errorf("encode: %w", err)
 	}
 
-	err = s.setPacketMode()
+	err = s.configure()
 	if err != nil {
-		return fmt.Errorf("packet mode: %w", err)
+		return fmt.Errorf("configure: %w", err)
 	}
 
 	err = s.req(CMD_START, raw, true)
@@ -289,7 +330,9 @@ func decodeAlert(raw []byte) (map[int]interface{}, error) {
 		case ATTR_ORIGIN:
 			ret[ATTR_ORIGIN] = dec.Uint16()
 		case ATTR_HW_TRAP_GROUP_NAME:
+			ret[ATTR_HW_TRAP_GROUP_NAME] = dec.String()
 		case ATTR_HW_TRAP_NAME:
+			ret[ATTR_HW_TRAP_NAME] = dec.String()
 		case ATTR_HW_ENTRIES:
 		case ATTR_HW_ENTRY:
 		case ATTR_HW_TRAP_COUNT:
@@ -303,11 +346,13 @@ func decodeAlert(raw []byte) (map[int]interface{}, error) {
 	return ret, nil
 }
 
-func (s *Session) setPacketMode() error {
+// configure pushes the Session's alert mode and truncation/queue length
+// down to DM_NET via an acknowledged CMD_CONFIG.
+func (s *Session) configure() error {
 	enc := netlink.NewAttributeEncoder()
-	enc.Uint8(ATTR_ALERT_MODE, ALERT_MODE_PACKET)
-	enc.Uint32(ATTR_TRUNC_LEN, 100)
-	enc.Uint32(ATTR_QUEUE_LEN, 4096)
+	enc.Uint8(ATTR_ALERT_MODE, s.alertMode)
+	enc.Uint32(ATTR_TRUNC_LEN, s.truncLen)
+	enc.Uint32(ATTR_QUEUE_LEN, s.queueLen)
 
 	raw, err := enc.Encode()
 	if err != nil {
@@ -367,6 +412,117 @@ func (s *Session) ReadUntil(deadline time.Time, f PacketAlertFunc) error {
 	}
 }
 
+// SummaryAlertFunc returns false if we should stop reading summaries now.
+type SummaryAlertFunc func(SummaryAlert) bool
+
+// ReadSummaryUntil reads summary-mode alerts until the deadline has
+// elapsed, calling `f` on each; read indefinitely if deadline is zero.
+// The Session must have been started with WithAlertMode(ALERT_MODE_SUMMARY).
+func (s *Session) ReadSummaryUntil(deadline time.Time, f SummaryAlertFunc) error {
+	s.conn.SetReadBuffer(4096)
+
+	for {
+		if !deadline.IsZero() {
+			s.conn.SetReadDeadline(deadline)
+		}
+		ms, _, err := s.conn.Receive()
+		if err != nil {
+			if nerr, ok := err.(net.Error); ok && nerr.Timeout() {
+				return nil
+			}
+
+			return fmt.Errorf("recv: %w", err)
+		}
+
+		for _, m := range ms {
+			if m.Header.Command != CMD_ALERT {
+				continue
+			}
+
+			sa, err := SummaryAlertFromRaw(m.Data)
+			if err != nil {
+				return fmt.Errorf("parse alert summary: %w", err)
+			}
+
+			if !f(sa) {
+				return nil
+			}
+		}
+	}
+}
+
+// SummaryPoint is a single drop-count entry from a summary-mode alert:
+// how many drops NET_DM has seen at a given kernel PC/symbol since the
+// last summary.
+type SummaryPoint struct {
+	Symbol    string
+	PC        uint64
+	DropCount uint64
+}
+
+// SummaryAlert wraps the per-PC/symbol drop counts parsed from a
+// summary-mode CMD_ALERT message's ATTR_STATS attribute.
+type SummaryAlert struct {
+	Points []SummaryPoint
+}
+
+func decodeSummaryAlert(raw []byte) ([]SummaryPoint, error) {
+	dec, err := netlink.NewAttributeDecoder(raw)
+	if err != nil {
+		return nil, fmt.Errorf("decode: %w", err)
+	}
+
+	var points []SummaryPoint
+
+	for dec.Next() {
+		if dec.Type() != ATTR_STATS {
+			continue
+		}
+
+		dec.Nested(func(d *netlink.AttributeDecoder) error {
+			for d.Next() {
+				var pt SummaryPoint
+
+				d.Nested(func(e *netlink.AttributeDecoder) error {
+					for e.Next() {
+						switch e.Type() {
+						case ATTR_PC:
+							pt.PC = e.Uint64()
+						case ATTR_SYMBOL:
+							pt.Symbol = e.String()
+						case NATTR_STATS_DROPPED:
+							pt.DropCount = e.Uint64()
+						}
+					}
+
+					return nil
+				})
+
+				points = append(points, pt)
+			}
+
+			return nil
+		})
+	}
+
+	if err := dec.Err(); err != nil {
+		return nil, err
+	}
+
+	return points, nil
+}
+
+// SummaryAlertFromRaw creates a SummaryAlert from the raw bytes of a
+// summary-mode CMD_ALERT message.
+func SummaryAlertFromRaw(raw []byte) (SummaryAlert, error) {
+	points, err := decodeSummaryAlert(raw)
+	if err != nil {
+		return SummaryAlert{}, fmt.Errorf("decode: %w", err)
+	}
+
+	return SummaryAlert{Points: points}, nil
+}
+
 // PacketAlert wraps the Netlink attributes parsed from a CMD_ALERT message
 type PacketAlert struct {
 	attrs map[int]interface{}
@@ -474,3 +630,80 @@ func (pa *PacketAlert) Link() uint32 {
 
 	return lidx.(uint32)
 }
+
+// Timestamp returns the kernel's wall-clock time at the moment of the
+// drop, from ATTR_TIMESTAMP; the zero time if the kernel didn't report one.
+func (pa *PacketAlert) Timestamp() time.Time {
+	ts, ok := pa.attrs[ATTR_TIMESTAMP]
+	if !ok {
+		return time.Time{}
+	}
+
+	return time.Unix(0, int64(ts.(uint64)))
+}
+
+// ResolvedSymbol resolves this drop's PC through r and returns
+// "name+offset", falling back to Symbol() when r can't resolve it (no
+// PC on this alert, or /proc/kallsyms addresses were hidden by
+// kptr_restrict).
+func (pa *PacketAlert) ResolvedSymbol(r *SymbolResolver) string {
+	if pc := pa.PC(); pc != 0 {
+		if name, offset := r.Resolve(pc); name != "" {
+			return fmt.Sprintf("%s+0x%x", name, offset)
+		}
+	}
+
+	return pa.Symbol()
+}
+
+// Origin identifies whether a drop happened in software (the kernel's
+// network stack) or hardware (offload silicon reporting via devlink).
+type Origin uint16
+
+const (
+	OriginSW Origin = ORIGIN_SW
+	OriginHW Origin = ORIGIN_HW
+)
+
+func (o Origin) String() string {
+	if o == OriginHW {
+		return "hw"
+	}
+
+	return "sw"
+}
+
+// Origin reports whether this drop was seen by software or by offload
+// hardware, from ATTR_ORIGIN; defaults to OriginSW when the kernel
+// doesn't report one.
+func (pa *PacketAlert) Origin() Origin {
+	o, ok := pa.attrs[ATTR_ORIGIN]
+	if !ok {
+		return OriginSW
+	}
+
+	return Origin(o.(uint16))
+}
+
+// HWTrapName returns the devlink trap name responsible for a hardware
+// drop (see Devlink for enumerating and arming traps),
+// or "" when this wasn't a hardware drop.
+func (pa *PacketAlert) HWTrapName() string {
+	name, ok := pa.attrs[ATTR_HW_TRAP_NAME]
+	if !ok {
+		return ""
+	}
+
+	return name.(string)
+}
+
+// HWTrapGroup returns the devlink trap group of the trap responsible
+// for a hardware drop, or "" when this wasn't a hardware drop.
+func (pa *PacketAlert) HWTrapGroup() string {
+	group, ok := pa.attrs[ATTR_HW_TRAP_GROUP_NAME]
+	if !ok {
+		return ""
+	}
+
+	return group.(string)
+}
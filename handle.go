@@ -0,0 +1,201 @@
+package dropspy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"runtime"
+	"syscall"
+	"time"
+
+	"github.com/vishvananda/netns"
+)
+
+// Handle wraps a Session bound to a specific network namespace, so one
+// process can watch drops across many network namespaces at once (as on
+// a container host, one namespace per container). It mirrors the Handle
+// pattern from github.com/vishvananda/netlink.
+type Handle struct {
+	*Session
+
+	ns netns.NsHandle
+}
+
+// NewHandle opens a Handle bound to the caller's current network
+// namespace; equivalent to wrapping NewSession directly.
+func NewHandle(opts ...Option) (*Handle, error) {
+	s, err := NewSession(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("handle: %w", err)
+	}
+
+	return &Handle{Session: s, ns: netns.None()}, nil
+}
+
+// NewHandleAt opens a Handle whose Session's netlink socket - and so
+// every drop it sees - belongs to ns rather than the caller's current
+// network namespace.
+func NewHandleAt(ns netns.NsHandle, opts ...Option) (*Handle, error) {
+	var (
+		s   *Session
+		err error
+	)
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		runtime.LockOSThread()
+		defer runtime.UnlockOSThread()
+
+		orig, nsErr := netns.Get()
+		if nsErr != nil {
+			err = fmt.Errorf("get current netns: %w", nsErr)
+			return
+		}
+		defer orig.Close()
+
+		if setErr := netns.Set(ns); setErr != nil {
+			err = fmt.Errorf("enter netns: %w", setErr)
+			return
+		}
+		defer netns.Set(orig)
+
+		s, err = NewSession(opts...)
+	}()
+	<-done
+
+	if err != nil {
+		return nil, fmt.Errorf("handle: %w", err)
+	}
+
+	return &Handle{Session: s, ns: ns}, nil
+}
+
+// NewHandleFromName opens a Handle bound to the named network namespace,
+// as created by `ip netns add` under /var/run/netns.
+func NewHandleFromName(name string, opts ...Option) (*Handle, error) {
+	ns, err := netns.GetFromName(name)
+	if err != nil {
+		return nil, fmt.Errorf("handle: %w", err)
+	}
+
+	return NewHandleAt(ns, opts...)
+}
+
+// NewHandleFromPid opens a Handle bound to the network namespace of pid,
+// via /proc/<pid>/ns/net.
+func NewHandleFromPid(pid int, opts ...Option) (*Handle, error) {
+	ns, err := netns.GetFromPid(pid)
+	if err != nil {
+		return nil, fmt.Errorf("handle: %w", err)
+	}
+
+	return NewHandleAt(ns, opts...)
+}
+
+// Close releases the Handle's Session and its network namespace handle.
+func (h *Handle) Close() error {
+	if err := h.Session.Close(); err != nil {
+		return err
+	}
+
+	if h.ns == netns.None() {
+		return nil
+	}
+
+	return h.ns.Close()
+}
+
+// QueueOverflowError means the kernel's netlink socket buffer
+// overflowed (ENOBUFS) before Subscribe's receive loop could drain it:
+// some alerts were lost between reads. It's reported distinctly from an
+// ordinary read error since it's recoverable - Subscribe keeps running.
+type QueueOverflowError struct {
+	Err error
+}
+
+func (e *QueueOverflowError) Error() string {
+	return fmt.Sprintf("alert queue overflow: %s", e.Err)
+}
+
+func (e *QueueOverflowError) Unwrap() error { return e.Err }
+
+// Subscribe runs the alert receive loop in its own goroutine, delivering
+// packet-mode alerts on the returned channel until ctx is canceled.
+// Errors are delivered on the error channel; a *QueueOverflowError
+// (the netlink socket buffer overflowed and alerts were lost) or an
+// alert that fails to parse doesn't end the subscription, but any other
+// read error does. Both channels are closed once the goroutine exits.
+func (h *Handle) Subscribe(ctx context.Context) (<-chan PacketAlert, <-chan error) {
+	alerts := make(chan PacketAlert, 64)
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(alerts)
+		defer close(errs)
+
+		// BUG(tqbf): voodoo; i have no idea if this matters
+		h.conn.SetReadBuffer(4096)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			default:
+			}
+
+			h.conn.SetReadDeadline(time.Now().Add(250 * time.Millisecond))
+
+			ms, _, err := h.conn.Receive()
+			if err != nil {
+				if nerr, ok := err.(net.Error); ok && nerr.Timeout() {
+					continue
+				}
+
+				if errors.Is(err, syscall.ENOBUFS) {
+					if !sendErr(ctx, errs, &QueueOverflowError{Err: err}) {
+						return
+					}
+					continue
+				}
+
+				sendErr(ctx, errs, fmt.Errorf("recv: %w", err))
+				return
+			}
+
+			for _, m := range ms {
+				if m.Header.Command != CMD_PACKET_ALERT {
+					continue
+				}
+
+				pa, err := PacketAlertFromRaw(m.Data)
+				if err != nil {
+					if !sendErr(ctx, errs, fmt.Errorf("parse alert packet: %w", err)) {
+						return
+					}
+					continue
+				}
+
+				select {
+				case alerts <- pa:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return alerts, errs
+}
+
+func sendErr(ctx context.Context, errs chan<- error, err error) bool {
+	select {
+	case errs <- err:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
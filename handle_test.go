@@ -0,0 +1,46 @@
+package dropspy
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestHandleSubscribe(t *testing.T) {
+	h, err := NewHandle()
+	if err != nil {
+		t.Fatalf("init: %s", err)
+	}
+	defer h.Close()
+
+	h.Stop(true, true)
+
+	if err := h.Start(true, false); err != nil {
+		t.Fatalf("start: %s", err)
+	}
+	defer h.Stop(true, true)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	alerts, errs := h.Subscribe(ctx)
+
+	for {
+		select {
+		case pa, ok := <-alerts:
+			if !ok {
+				return
+			}
+
+			t.Logf("drop at %s:%016x", pa.Symbol(), pa.PC())
+		case err, ok := <-errs:
+			if !ok {
+				return
+			}
+
+			t.Fatalf("subscribe: %s", err)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package dropspy
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type symbol struct {
+	addr uint64
+	name string
+}
+
+// SymbolResolver resolves kernel program counters to symbol names and
+// offsets, from a one-time parse of /proc/kallsyms.
+type SymbolResolver struct {
+	syms []symbol
+}
+
+// NewSymbolResolver parses /proc/kallsyms into a sorted, binary-searchable
+// table. If kptr_restrict is hiding real addresses, every address reads
+// back as zero and gets skipped; NewSymbolResolver still succeeds, it
+// just returns a resolver that can't resolve anything, since the file
+// itself was readable.
+func NewSymbolResolver() (*SymbolResolver, error) {
+	f, err := os.Open("/proc/kallsyms")
+	if err != nil {
+		return nil, fmt.Errorf("resolver: %w", err)
+	}
+	defer f.Close()
+
+	var syms []symbol
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 {
+			continue
+		}
+
+		addr, err := strconv.ParseUint(fields[0], 16, 64)
+		if err != nil || addr == 0 {
+			continue
+		}
+
+		syms = append(syms, symbol{addr: addr, name: fields[2]})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("resolver: %w", err)
+	}
+
+	sort.Slice(syms, func(i, j int) bool { return syms[i].addr < syms[j].addr })
+
+	return &SymbolResolver{syms: syms}, nil
+}
+
+// Resolve returns the name of the symbol whose address is the greatest
+// one not exceeding pc, and pc's offset from it. name is "" if pc falls
+// before every known symbol, or the table is empty (every address
+// hidden by kptr_restrict).
+func (r *SymbolResolver) Resolve(pc uint64) (name string, offset uint64) {
+	i := sort.Search(len(r.syms), func(i int) bool { return r.syms[i].addr > pc }) - 1
+	if i < 0 {
+		return "", 0
+	}
+
+	return r.syms[i].name, pc - r.syms[i].addr
+}
@@ -0,0 +1,41 @@
+package dropspy
+
+import "testing"
+
+func TestSymbolResolverResolve(t *testing.T) {
+	r := &SymbolResolver{syms: []symbol{
+		{addr: 0x1000, name: "foo"},
+		{addr: 0x2000, name: "bar"},
+		{addr: 0x3000, name: "baz"},
+	}}
+
+	cases := []struct {
+		name       string
+		pc         uint64
+		wantName   string
+		wantOffset uint64
+	}{
+		{"exact match", 0x2000, "bar", 0},
+		{"before first symbol", 0x500, "", 0},
+		{"between symbols", 0x2123, "bar", 0x123},
+		{"after last symbol", 0x3456, "baz", 0x456},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			name, offset := r.Resolve(c.pc)
+			if name != c.wantName || offset != c.wantOffset {
+				t.Fatalf("Resolve(%#x) = (%q, %#x), want (%q, %#x)", c.pc, name, offset, c.wantName, c.wantOffset)
+			}
+		})
+	}
+}
+
+func TestSymbolResolverResolveEmpty(t *testing.T) {
+	r := &SymbolResolver{}
+
+	name, offset := r.Resolve(0x1234)
+	if name != "" || offset != 0 {
+		t.Fatalf("Resolve() on empty table = (%q, %#x), want (\"\", 0)", name, offset)
+	}
+}
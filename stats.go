@@ -0,0 +1,192 @@
+package dropspy
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mdlayher/netlink"
+)
+
+// Stats holds the kernel's own drop-monitor counters, as reported by
+// CMD_STATS_GET. These are separate from (and, since the alert queue is
+// bounded - 4096 entries by default - can run ahead of) whatever Session.ReadUntil
+// has actually delivered - a growing gap between the two means alerts
+// are being lost to queue overrun.
+type Stats struct {
+	// SWDropped is the total number of packets dropped in software
+	// since drop monitoring was enabled.
+	SWDropped uint64
+
+	// HWDropped is the total number of packets dropped by offload
+	// hardware since drop monitoring was enabled.
+	HWDropped uint64
+
+	// HWTrapCounts breaks HWDropped down by devlink trap name, when the
+	// kernel reports per-trap counters (ATTR_HW_ENTRIES); nil otherwise.
+	HWTrapCounts map[string]uint32
+}
+
+func decodeStats(raw []byte) (Stats, error) {
+	dec, err := netlink.NewAttributeDecoder(raw)
+	if err != nil {
+		return Stats{}, fmt.Errorf("decode: %w", err)
+	}
+
+	var st Stats
+
+	for dec.Next() {
+		switch dec.Type() {
+		case ATTR_STATS:
+			dec.Nested(func(d *netlink.AttributeDecoder) error {
+				for d.Next() {
+					if d.Type() == NATTR_STATS_DROPPED {
+						st.SWDropped = d.Uint64()
+					}
+				}
+
+				return nil
+			})
+		case ATTR_HW_STATS:
+			dec.Nested(func(d *netlink.AttributeDecoder) error {
+				for d.Next() {
+					if d.Type() == NATTR_STATS_DROPPED {
+						st.HWDropped = d.Uint64()
+					}
+				}
+
+				return nil
+			})
+		case ATTR_HW_ENTRIES:
+			dec.Nested(func(d *netlink.AttributeDecoder) error {
+				for d.Next() {
+					var name string
+					var count uint32
+
+					d.Nested(func(e *netlink.AttributeDecoder) error {
+						for e.Next() {
+							switch e.Type() {
+							case ATTR_HW_TRAP_NAME:
+								name = e.String()
+							case ATTR_HW_TRAP_COUNT:
+								count = e.Uint32()
+							}
+						}
+
+						return nil
+					})
+
+					if name != "" {
+						if st.HWTrapCounts == nil {
+							st.HWTrapCounts = map[string]uint32{}
+						}
+
+						st.HWTrapCounts[name] = count
+					}
+				}
+
+				return nil
+			})
+		}
+	}
+
+	if err := dec.Err(); err != nil {
+		return Stats{}, err
+	}
+
+	return st, nil
+}
+
+// Stats issues a CMD_STATS_GET and returns the kernel's current
+// cumulative drop counters.
+func (s *Session) Stats() (Stats, error) {
+	err := s.req(CMD_STATS_GET, nil, false)
+	if err != nil {
+		return Stats{}, fmt.Errorf("stats: %w", err)
+	}
+
+	ms, _, err := s.conn.Receive()
+	if err != nil {
+		return Stats{}, fmt.Errorf("stats: %w", err)
+	}
+
+	st, err := decodeStats(ms[0].Data)
+	if err != nil {
+		return Stats{}, fmt.Errorf("stats: %w", err)
+	}
+
+	return st, nil
+}
+
+// delta64 returns cur-prev, or cur if the counter has gone backwards
+// (kernel reboot, module reload, or a wraparound), rather than
+// underflowing to a bogus near-max value.
+func delta64(prev, cur uint64) uint64 {
+	if cur < prev {
+		return cur
+	}
+
+	return cur - prev
+}
+
+// delta32 is delta64 for the per-trap uint32 counters.
+func delta32(prev, cur uint32) uint32 {
+	if cur < prev {
+		return cur
+	}
+
+	return cur - prev
+}
+
+func statsDelta(prev, cur Stats) Stats {
+	d := Stats{
+		SWDropped: delta64(prev.SWDropped, cur.SWDropped),
+		HWDropped: delta64(prev.HWDropped, cur.HWDropped),
+	}
+
+	if cur.HWTrapCounts != nil {
+		d.HWTrapCounts = map[string]uint32{}
+
+		for name, count := range cur.HWTrapCounts {
+			d.HWTrapCounts[name] = delta32(prev.HWTrapCounts[name], count)
+		}
+	}
+
+	return d
+}
+
+// WatchStats polls Stats every interval and calls f with the delta since
+// the previous poll (not the running totals), so callers can watch for
+// drops accumulating faster than they're being read. It returns a stop
+// function that ends the polling goroutine.
+func (s *Session) WatchStats(interval time.Duration, f func(Stats)) (stop func()) {
+	done := make(chan struct{})
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		prev, havePrev := Stats{}, false
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				cur, err := s.Stats()
+				if err != nil {
+					continue
+				}
+
+				if havePrev {
+					f(statsDelta(prev, cur))
+				}
+
+				prev, havePrev = cur, true
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+	}
+}
@@ -0,0 +1,132 @@
+package dropspy
+
+import (
+	"testing"
+
+	"github.com/mdlayher/netlink"
+)
+
+func encodeStats(t *testing.T, sw, hw uint64, traps map[string]uint32) []byte {
+	t.Helper()
+
+	enc := netlink.NewAttributeEncoder()
+
+	enc.Nested(ATTR_STATS, func(d *netlink.AttributeEncoder) error {
+		d.Uint64(NATTR_STATS_DROPPED, sw)
+		return nil
+	})
+
+	enc.Nested(ATTR_HW_STATS, func(d *netlink.AttributeEncoder) error {
+		d.Uint64(NATTR_STATS_DROPPED, hw)
+		return nil
+	})
+
+	if traps != nil {
+		enc.Nested(ATTR_HW_ENTRIES, func(d *netlink.AttributeEncoder) error {
+			for name, count := range traps {
+				d.Nested(ATTR_HW_ENTRY, func(e *netlink.AttributeEncoder) error {
+					e.String(ATTR_HW_TRAP_NAME, name)
+					e.Uint32(ATTR_HW_TRAP_COUNT, count)
+					return nil
+				})
+			}
+
+			return nil
+		})
+	}
+
+	raw, err := enc.Encode()
+	if err != nil {
+		t.Fatalf("encode: %s", err)
+	}
+
+	return raw
+}
+
+func TestDecodeStats(t *testing.T) {
+	raw := encodeStats(t, 10, 5, map[string]uint32{"trap0": 3})
+
+	st, err := decodeStats(raw)
+	if err != nil {
+		t.Fatalf("decodeStats: %s", err)
+	}
+
+	if st.SWDropped != 10 {
+		t.Errorf("SWDropped = %d, want 10", st.SWDropped)
+	}
+
+	if st.HWDropped != 5 {
+		t.Errorf("HWDropped = %d, want 5", st.HWDropped)
+	}
+
+	if st.HWTrapCounts["trap0"] != 3 {
+		t.Errorf("HWTrapCounts[trap0] = %d, want 3", st.HWTrapCounts["trap0"])
+	}
+}
+
+func TestDecodeStatsNoHWEntries(t *testing.T) {
+	raw := encodeStats(t, 1, 0, nil)
+
+	st, err := decodeStats(raw)
+	if err != nil {
+		t.Fatalf("decodeStats: %s", err)
+	}
+
+	if st.HWTrapCounts != nil {
+		t.Errorf("HWTrapCounts = %v, want nil", st.HWTrapCounts)
+	}
+}
+
+func TestStatsDelta(t *testing.T) {
+	prev := Stats{
+		SWDropped:    10,
+		HWDropped:    4,
+		HWTrapCounts: map[string]uint32{"trap0": 2},
+	}
+	cur := Stats{
+		SWDropped:    15,
+		HWDropped:    4,
+		HWTrapCounts: map[string]uint32{"trap0": 9},
+	}
+
+	d := statsDelta(prev, cur)
+
+	if d.SWDropped != 5 {
+		t.Errorf("SWDropped delta = %d, want 5", d.SWDropped)
+	}
+
+	if d.HWDropped != 0 {
+		t.Errorf("HWDropped delta = %d, want 0", d.HWDropped)
+	}
+
+	if d.HWTrapCounts["trap0"] != 7 {
+		t.Errorf("HWTrapCounts[trap0] delta = %d, want 7", d.HWTrapCounts["trap0"])
+	}
+}
+
+func TestStatsDeltaReset(t *testing.T) {
+	prev := Stats{
+		SWDropped:    1000,
+		HWDropped:    500,
+		HWTrapCounts: map[string]uint32{"trap0": 50},
+	}
+	cur := Stats{
+		SWDropped:    3,
+		HWDropped:    500,
+		HWTrapCounts: map[string]uint32{"trap0": 2},
+	}
+
+	d := statsDelta(prev, cur)
+
+	if d.SWDropped != 3 {
+		t.Errorf("SWDropped delta after reset = %d, want 3 (cur value)", d.SWDropped)
+	}
+
+	if d.HWDropped != 0 {
+		t.Errorf("HWDropped delta = %d, want 0", d.HWDropped)
+	}
+
+	if d.HWTrapCounts["trap0"] != 2 {
+		t.Errorf("HWTrapCounts[trap0] delta after reset = %d, want 2 (cur value)", d.HWTrapCounts["trap0"])
+	}
+}